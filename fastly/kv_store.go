@@ -1,10 +1,16 @@
 package fastly
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
+	mrand "math/rand"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -206,10 +212,18 @@ func (c *Client) DeleteKVStore(i *DeleteKVStoreInput) error {
 type ListKVStoreKeysInput struct {
 	// Cursor is used for paginating through results.
 	Cursor string
+	// Delimiter collapses keys sharing a path segment after Prefix into a
+	// single CommonPrefixes entry (e.g. "foo/bar/baz" under Prefix "foo/"
+	// becomes the common prefix "foo/bar/" when Delimiter is "/"), the way a
+	// directory listing groups its immediate children. Emulated client-side,
+	// since the Fastly API has no concept of delimited listings.
+	Delimiter string
 	// ID is the ID of the kv store to list keys for (required).
 	ID string
 	// Limit is the maximum number of items included the response.
 	Limit int
+	// Prefix restricts the listing to keys sharing this byte-prefix.
+	Prefix string
 }
 
 func (l *ListKVStoreKeysInput) formatFilters() map[string]string {
@@ -217,7 +231,7 @@ func (l *ListKVStoreKeysInput) formatFilters() map[string]string {
 		return nil
 	}
 
-	if l.Limit == 0 && l.Cursor == "" {
+	if l.Limit == 0 && l.Cursor == "" && l.Prefix == "" {
 		return nil
 	}
 
@@ -231,15 +245,34 @@ func (l *ListKVStoreKeysInput) formatFilters() map[string]string {
 		m["cursor"] = l.Cursor
 	}
 
+	if l.Prefix != "" {
+		m["prefix"] = l.Prefix
+	}
+
 	return m
 }
 
 // ListKVStoreKeysResponse retrieves all resources.
 type ListKVStoreKeysResponse struct {
+	// CommonPrefixes holds the immediate child path segments below Prefix,
+	// collapsed the way a directory listing folds a subtree into one entry.
+	// Only populated when Delimiter was set on the request.
+	//
+	// Deduplication happens within this response only: a common prefix whose
+	// children straddle a page boundary can reappear in the CommonPrefixes of
+	// more than one page. Callers that page through results themselves,
+	// rather than consuming a single response (DeleteKVStoreKeysByPrefix,
+	// BulkExportKVStore, and WatchKVStore all do this internally), must
+	// dedupe CommonPrefixes across pages.
+	CommonPrefixes []string
 	// Data is the list of keys
 	Data []string
 	// Meta is the information for pagination
 	Meta map[string]string
+	// PrefixMatchedKeys holds the keys from Data that were not folded into a
+	// CommonPrefixes entry, i.e. those with no further path segment after
+	// Prefix. Only populated when Delimiter was set on the request.
+	PrefixMatchedKeys []string
 }
 
 // ListKVStoreKeys retrieves all resources.
@@ -248,22 +281,53 @@ func (c *Client) ListKVStoreKeys(i *ListKVStoreKeysInput) (*ListKVStoreKeysRespo
 		return nil, ErrMissingID
 	}
 
-	path := "/resources/stores/kv/" + i.ID + "/keys"
-	ro := new(RequestOptions)
-	ro.Params = i.formatFilters()
+	var output *ListKVStoreKeysResponse
+	err := c.observeKVStoreOp(context.Background(), "list", i.ID, "", func() error {
+		path := "/resources/stores/kv/" + i.ID + "/keys"
+		ro := new(RequestOptions)
+		ro.Params = i.formatFilters()
 
-	resp, err := c.Get(path, ro)
+		resp, err := c.Get(path, ro)
+		if err != nil {
+			return err
+		}
+
+		if err := decodeBodyMap(resp.Body, &output); err != nil {
+			return err
+		}
+
+		if i.Delimiter != "" {
+			foldKVStoreKeysByDelimiter(output, i.Prefix, i.Delimiter)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var output *ListKVStoreKeysResponse
-	if err := decodeBodyMap(resp.Body, &output); err != nil {
-		return nil, err
-	}
 	return output, nil
 }
 
+// foldKVStoreKeysByDelimiter splits output.Data into PrefixMatchedKeys and
+// CommonPrefixes, folding every key that has a further path segment after
+// prefix into a single deduplicated CommonPrefixes entry.
+func foldKVStoreKeysByDelimiter(output *ListKVStoreKeysResponse, prefix, delimiter string) {
+	seen := make(map[string]bool)
+	for _, key := range output.Data {
+		rest := strings.TrimPrefix(key, prefix)
+		if idx := strings.Index(rest, delimiter); idx >= 0 {
+			common := prefix + rest[:idx+len(delimiter)]
+			if !seen[common] {
+				seen[common] = true
+				output.CommonPrefixes = append(output.CommonPrefixes, common)
+			}
+			continue
+		}
+		output.PrefixMatchedKeys = append(output.PrefixMatchedKeys, key)
+	}
+}
+
 // ListKVStoreKeysPaginator is the opaque type for a ListKVStoreKeys calls with pagination.
 type ListKVStoreKeysPaginator struct {
 	client   *Client
@@ -294,6 +358,8 @@ func (l *ListKVStoreKeysPaginator) Next() bool {
 	if err != nil {
 		l.err = err
 		l.finished = true
+		l.keys = nil
+		return false
 	}
 
 	l.keys = o.Data
@@ -316,6 +382,41 @@ func (l *ListKVStoreKeysPaginator) Keys() []string {
 	return l.keys
 }
 
+// DeleteKVStoreKeysByPrefixInput is the input to the DeleteKVStoreKeysByPrefix function.
+type DeleteKVStoreKeysByPrefixInput struct {
+	// ID is the ID of the kv store (required).
+	ID string
+	// Prefix is the key prefix to delete (required).
+	Prefix string
+}
+
+// DeleteKVStoreKeysByPrefix deletes every key sharing Prefix by walking the
+// key paginator and deleting each match, giving callers a "delete tree"
+// primitive on top of the regular key-at-a-time delete.
+func (c *Client) DeleteKVStoreKeysByPrefix(i *DeleteKVStoreKeysByPrefixInput) error {
+	if i.ID == "" {
+		return ErrMissingID
+	}
+	if i.Prefix == "" {
+		return ErrMissingKey
+	}
+
+	paginator := c.NewListKVStoreKeysPaginator(&ListKVStoreKeysInput{
+		ID:     i.ID,
+		Prefix: i.Prefix,
+	})
+
+	for paginator.Next() {
+		for _, key := range paginator.Keys() {
+			if err := c.DeleteKVStoreKey(&DeleteKVStoreKeyInput{ID: i.ID, Key: key}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return paginator.Err()
+}
+
 // GetKVStoreKeyInput is the input to the GetKVStoreKey function.
 type GetKVStoreKeyInput struct {
 	// ID is the ID of the kv store (required).
@@ -333,18 +434,88 @@ func (c *Client) GetKVStoreKey(i *GetKVStoreKeyInput) (string, error) {
 		return "", ErrMissingKey
 	}
 
-	path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
-	resp, err := c.Get(path, nil)
-	if err != nil {
-		return "", err
-	}
+	var output string
+	err := c.observeKVStoreOp(context.Background(), "get", i.ID, i.Key, func() error {
+		path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
+		resp, err := c.Get(path, nil)
+		if err != nil {
+			return err
+		}
 
-	output, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		output = string(body)
+		return nil
+	})
+	return output, err
+}
+
+// KVStoreKeyMetadata holds a key's value together with the response metadata
+// needed to perform a compare-and-swap write via InsertKVStoreKeyAtomic or
+// DeleteKVStoreKeyAtomic.
+type KVStoreKeyMetadata struct {
+	// ETag is the opaque version identifier the Fastly API returned for this
+	// key, suitable for use as an If-Match precondition.
+	ETag string
+	// Generation is the store-assigned generation number for this key, if the
+	// API exposes one.
+	Generation string
+	// LastModified is the time the key was last written, if the API exposes it.
+	LastModified *time.Time
+	// Value is the current value stored under the key.
+	Value string
+}
+
+// GetKVStoreKeyWithMetadata retrieves the specified resource along with its
+// ETag (and any Last-Modified/generation headers), so the caller can perform
+// a subsequent InsertKVStoreKeyAtomic or DeleteKVStoreKeyAtomic.
+func (c *Client) GetKVStoreKeyWithMetadata(i *GetKVStoreKeyInput) (*KVStoreKeyMetadata, error) {
+	return c.getKVStoreKeyWithMetadata(context.Background(), i)
+}
+
+// getKVStoreKeyWithMetadata is GetKVStoreKeyWithMetadata with an explicit
+// context, so callers that already have a real request context (such as the
+// watch poll loop) can have it observed under the right span instead of a
+// detached context.Background().
+func (c *Client) getKVStoreKeyWithMetadata(ctx context.Context, i *GetKVStoreKeyInput) (*KVStoreKeyMetadata, error) {
+	if i.ID == "" {
+		return nil, ErrMissingID
+	}
+	if i.Key == "" {
+		return nil, ErrMissingKey
 	}
 
-	return string(output), nil
+	var meta *KVStoreKeyMetadata
+	err := c.observeKVStoreOp(ctx, "get_metadata", i.ID, i.Key, func() error {
+		path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
+		resp, err := c.Get(path, nil)
+		if err != nil {
+			return err
+		}
+
+		value, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		meta = &KVStoreKeyMetadata{
+			ETag:       resp.Header.Get("ETag"),
+			Generation: resp.Header.Get("Generation"),
+			Value:      string(value),
+		}
+
+		if lm := resp.Header.Get("Last-Modified"); lm != "" {
+			if t, err := http.ParseTime(lm); err == nil {
+				meta.LastModified = &t
+			}
+		}
+
+		return nil
+	})
+	return meta, err
 }
 
 // InsertKVStoreKeyInput is the input to the InsertKVStoreKey function.
@@ -366,14 +537,16 @@ func (c *Client) InsertKVStoreKey(i *InsertKVStoreKeyInput) error {
 		return ErrMissingKey
 	}
 
-	path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
-	resp, err := c.Put(path, &RequestOptions{Body: io.NopCloser(strings.NewReader(i.Value))})
-	if err != nil {
-		return err
-	}
+	return c.observeKVStoreOp(context.Background(), "insert", i.ID, i.Key, func() error {
+		path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
+		resp, err := c.Put(path, &RequestOptions{Body: io.NopCloser(strings.NewReader(i.Value))})
+		if err != nil {
+			return err
+		}
 
-	_, err = checkResp(resp, err)
-	return err
+		_, err = checkResp(resp, err)
+		return err
+	})
 }
 
 // DeleteKVStoreKeyInput is the input to the DeleteKVStoreKey function.
@@ -393,15 +566,905 @@ func (c *Client) DeleteKVStoreKey(i *DeleteKVStoreKeyInput) error {
 		return ErrMissingKey
 	}
 
-	path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
-	resp, err := c.Delete(path, nil)
-	if err != nil {
+	return c.observeKVStoreOp(context.Background(), "delete", i.ID, i.Key, func() error {
+		path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
+		resp, err := c.Delete(path, nil)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusNoContent {
+			return NewHTTPError(resp)
+		}
+
+		return nil
+	})
+}
+
+// ErrKVStoreCASFailure is returned by InsertKVStoreKeyAtomic and
+// DeleteKVStoreKeyAtomic when the request's If-Match (or If-None-Match: *)
+// precondition did not hold, i.e. the Fastly API responded 412 Precondition
+// Failed because the key was modified concurrently.
+var ErrKVStoreCASFailure = errors.New("fastly: kv store compare-and-swap failed: key was modified concurrently")
+
+// ErrMissingETag is returned by InsertKVStoreKeyAtomic and
+// DeleteKVStoreKeyAtomic when neither ETag nor CreateOnly is set. Without
+// one of these, the call has no precondition to enforce, so it is rejected
+// rather than silently falling back to an unconditional write or delete.
+var ErrMissingETag = errors.New("fastly: one of ETag or CreateOnly is required")
+
+// InsertKVStoreKeyAtomicInput is the input to the InsertKVStoreKeyAtomic function.
+type InsertKVStoreKeyAtomicInput struct {
+	// CreateOnly, when set, sends If-None-Match: * so the write only succeeds
+	// if the key does not already exist. Mutually exclusive with ETag; one of
+	// the two is required, or the call returns ErrMissingETag.
+	CreateOnly bool
+	// ETag is the value previously read via GetKVStoreKeyWithMetadata; the
+	// write is only applied if the key's current ETag still matches. One of
+	// ETag or CreateOnly is required, or the call returns ErrMissingETag.
+	ETag string
+	// ID is the ID of the kv store (required).
+	ID string
+	// Key is the key to add (required).
+	Key string
+	// Value is the value to insert (required).
+	Value string
+}
+
+// InsertKVStoreKeyAtomic performs an optimistic-concurrency write, guarding
+// the insert with an If-Match (or If-None-Match: * for CreateOnly) header so
+// callers can safely read-modify-write a key shared between actors. It
+// returns ErrMissingETag if neither ETag nor CreateOnly is set, rather than
+// falling back to an unconditional write, and ErrKVStoreCASFailure if the
+// Fastly API rejects the precondition.
+func (c *Client) InsertKVStoreKeyAtomic(i *InsertKVStoreKeyAtomicInput) error {
+	if i.ID == "" {
+		return ErrMissingID
+	}
+	if i.Key == "" {
+		return ErrMissingKey
+	}
+	if !i.CreateOnly && i.ETag == "" {
+		return ErrMissingETag
+	}
+
+	return c.observeKVStoreOp(context.Background(), "insert_atomic", i.ID, i.Key, func() error {
+		headers := make(map[string]string)
+		switch {
+		case i.CreateOnly:
+			headers["If-None-Match"] = "*"
+		case i.ETag != "":
+			headers["If-Match"] = i.ETag
+		}
+
+		path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
+		resp, err := c.Put(path, &RequestOptions{
+			Body:    io.NopCloser(strings.NewReader(i.Value)),
+			Headers: headers,
+		})
+		if resp != nil && resp.StatusCode == http.StatusPreconditionFailed {
+			return ErrKVStoreCASFailure
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = checkResp(resp, err)
 		return err
+	})
+}
+
+// DeleteKVStoreKeyAtomicInput is the input to the DeleteKVStoreKeyAtomic function.
+type DeleteKVStoreKeyAtomicInput struct {
+	// ETag is the value previously read via GetKVStoreKeyWithMetadata; the
+	// delete is only applied if the key's current ETag still matches. Required,
+	// or the call returns ErrMissingETag.
+	ETag string
+	// ID is the ID of the kv store (required).
+	ID string
+	// Key is the key to delete (required).
+	Key string
+}
+
+// DeleteKVStoreKeyAtomic deletes the specified resource guarded by an
+// If-Match precondition. It returns ErrMissingETag if ETag is not set,
+// rather than falling back to an unconditional delete, and
+// ErrKVStoreCASFailure if the key was modified concurrently.
+func (c *Client) DeleteKVStoreKeyAtomic(i *DeleteKVStoreKeyAtomicInput) error {
+	if i.ID == "" {
+		return ErrMissingID
+	}
+	if i.Key == "" {
+		return ErrMissingKey
+	}
+	if i.ETag == "" {
+		return ErrMissingETag
 	}
 
-	if resp.StatusCode != http.StatusNoContent {
-		return NewHTTPError(resp)
+	return c.observeKVStoreOp(context.Background(), "delete_atomic", i.ID, i.Key, func() error {
+		headers := make(map[string]string)
+		if i.ETag != "" {
+			headers["If-Match"] = i.ETag
+		}
+
+		path := "/resources/stores/kv/" + i.ID + "/keys/" + i.Key
+		resp, err := c.Delete(path, &RequestOptions{Headers: headers})
+		if resp != nil && resp.StatusCode == http.StatusPreconditionFailed {
+			return ErrKVStoreCASFailure
+		}
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusNoContent {
+			return NewHTTPError(resp)
+		}
+
+		return nil
+	})
+}
+
+// KVStoreEventType identifies the kind of change a KVStoreWatcher observed.
+type KVStoreEventType int
+
+const (
+	// KVStoreEventPut indicates a key was created or its value changed.
+	KVStoreEventPut KVStoreEventType = iota
+	// KVStoreEventDelete indicates a key was removed.
+	KVStoreEventDelete
+)
+
+// KVStoreEvent describes a single change observed by a KVStoreWatcher.
+type KVStoreEvent struct {
+	// Cursor is the listing cursor the event was observed at.
+	Cursor string
+	// ETag is the key's ETag at the time of observation, if known.
+	ETag string
+	// Key is the key that changed.
+	Key string
+	// Type indicates whether the key was put or deleted.
+	Type KVStoreEventType
+	// Value is the key's value after the change. Empty for deletes.
+	Value string
+}
+
+// WatchKVStoreInput is the input to the WatchKVStore function.
+type WatchKVStoreInput struct {
+	// ID is the ID of the kv store to watch (required).
+	ID string
+	// MaxInterval is the longest poll interval the watcher backs off to when
+	// nothing has changed. Defaults to 30s.
+	MaxInterval time.Duration
+	// MinInterval is the poll interval the watcher uses immediately after a
+	// change is observed. Defaults to 1s.
+	MinInterval time.Duration
+	// Prefix, when set, restricts the watcher to keys sharing this prefix.
+	Prefix string
+}
+
+// KVStoreWatcher delivers KVStoreEvents for a kv store, started by
+// WatchKVStore, until Stop is called or the watcher's context is cancelled.
+type KVStoreWatcher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+	events chan KVStoreEvent
+}
+
+// Events returns the channel change events are delivered on. It is closed
+// once the watcher stops.
+func (w *KVStoreWatcher) Events() <-chan KVStoreEvent {
+	return w.events
+}
+
+// Err returns the error that stopped the watcher, if any.
+func (w *KVStoreWatcher) Err() error {
+	return w.err
+}
+
+// Stop cancels the watcher's poll loop and waits for it to exit.
+func (w *KVStoreWatcher) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+// WatchKVStore starts a long-poll loop over ListKVStoreKeys, diffing
+// successive listings against a per-key ETag map and emitting a
+// KVStoreEvent for every key that was added, changed, or removed. The poll
+// interval backs off exponentially from MinInterval to MaxInterval while
+// nothing changes, and resets to MinInterval as soon as a change is
+// observed. The returned watcher honors ctx for cancellation.
+//
+// Each poll fetches the full value of every key under Prefix (not just a
+// cheap listing/ETag check), so the cost of a single poll is O(keys under
+// Prefix) full-body GETs. Scope Prefix tightly, or expect a correspondingly
+// larger per-poll cost, on stores with many keys.
+func (c *Client) WatchKVStore(ctx context.Context, i *WatchKVStoreInput) (*KVStoreWatcher, error) {
+	if i.ID == "" {
+		return nil, ErrMissingID
 	}
 
-	return nil
+	minInterval := i.MinInterval
+	if minInterval == 0 {
+		minInterval = time.Second
+	}
+	maxInterval := i.MaxInterval
+	if maxInterval == 0 {
+		maxInterval = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &KVStoreWatcher{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		events: make(chan KVStoreEvent),
+	}
+
+	go c.watchKVStoreLoop(ctx, w, i, minInterval, maxInterval)
+
+	return w, nil
+}
+
+// watchKVStoreLoop is the long-poll loop driving a KVStoreWatcher. It runs
+// until ctx is cancelled or a listing/fetch fails, in which case the error is
+// recorded on w and surfaced via Err. A 404 fetching an individual key is not
+// treated as a fatal failure: the key was present in the listing moments
+// earlier, so a 404 on its own fetch almost always means it was deleted in
+// between, and is reported as a KVStoreEventDelete instead of killing the
+// watcher.
+func (c *Client) watchKVStoreLoop(ctx context.Context, w *KVStoreWatcher, i *WatchKVStoreInput, minInterval, maxInterval time.Duration) {
+	defer close(w.done)
+	defer close(w.events)
+
+	seen := make(map[string]string) // key -> etag
+	interval := minInterval
+	var cursor string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		current := make(map[string]string)
+		changed := false
+
+		paginator := c.NewListKVStoreKeysPaginator(&ListKVStoreKeysInput{ID: i.ID, Prefix: i.Prefix})
+		for paginator.Next() {
+			cursor = paginator.input.Cursor
+			for _, key := range paginator.Keys() {
+				meta, err := c.getKVStoreKeyWithMetadata(ctx, &GetKVStoreKeyInput{ID: i.ID, Key: key})
+				if err != nil {
+					var httpErr *HTTPError
+					if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+						// The key was listed a moment ago but is gone by the
+						// time we fetch it individually — an ordinary race
+						// under write load, not a fatal watcher error. Treat
+						// it the same as a key that disappeared between
+						// polls: emit a delete and keep watching.
+						if prev, ok := seen[key]; ok {
+							changed = true
+							ev := KVStoreEvent{Key: key, Type: KVStoreEventDelete, ETag: prev, Cursor: cursor}
+							select {
+							case w.events <- ev:
+							case <-ctx.Done():
+								return
+							}
+							delete(seen, key) // already reported; keep the sweep below from repeating it
+						}
+						continue
+					}
+					w.err = err
+					return
+				}
+
+				current[key] = meta.ETag
+				if prev, ok := seen[key]; !ok || prev != meta.ETag {
+					changed = true
+					ev := KVStoreEvent{Key: key, Type: KVStoreEventPut, Value: meta.Value, ETag: meta.ETag, Cursor: cursor}
+					select {
+					case w.events <- ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+		if err := paginator.Err(); err != nil {
+			w.err = err
+			return
+		}
+
+		for key, etag := range seen {
+			if _, ok := current[key]; !ok {
+				changed = true
+				ev := KVStoreEvent{Key: key, Type: KVStoreEventDelete, ETag: etag, Cursor: cursor}
+				select {
+				case w.events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		seen = current
+
+		if changed {
+			interval = minInterval
+		} else {
+			interval *= 2
+			if interval > maxInterval {
+				interval = maxInterval
+			}
+		}
+	}
+}
+
+// KVStoreKeyValue is a single key/value pair used by the bulk KV store
+// operations.
+type KVStoreKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// BulkResult reports the outcome of a single key processed by a bulk
+// operation.
+type BulkResult struct {
+	// Attempts is the number of attempts made, including the final one.
+	Attempts int
+	// Err is the error the key ultimately failed with, or nil on success.
+	Err error
+	// Key is the key the result corresponds to.
+	Key string
+}
+
+// BulkStats summarizes a completed bulk operation.
+type BulkStats struct {
+	// Duration is the wall-clock time the operation took.
+	Duration time.Duration
+	// Failed is the number of keys that did not succeed.
+	Failed int
+	// Succeeded is the number of keys written or deleted successfully.
+	Succeeded int
+}
+
+// BulkInsertKVStoreKeysInput is the input to the BulkInsertKVStoreKeys function.
+type BulkInsertKVStoreKeysInput struct {
+	// ID is the ID of the kv store (required).
+	ID string
+	// Keys streams the key/value pairs to insert. Mutually exclusive with
+	// Reader; if both are nil, nothing is inserted.
+	Keys <-chan KVStoreKeyValue
+	// Parallelism is the number of worker goroutines to insert with. Defaults to 1.
+	Parallelism int
+	// Reader, if set, is read as newline-delimited JSON KVStoreKeyValue
+	// records (Value base64-encoded for binary data). Mutually exclusive
+	// with Keys.
+	Reader io.Reader
+	// Results, if set, receives a BulkResult for every key processed and is
+	// closed when the operation completes.
+	Results chan<- BulkResult
+	// StopOnError stops dispatching further keys as soon as one fails.
+	StopOnError bool
+}
+
+// BulkInsertKVStoreKeys inserts many keys concurrently using a bounded pool
+// of worker goroutines, retrying each key on 429/5xx responses with a
+// jittered exponential backoff that honors the response's Retry-After
+// header. It blocks until every key has been dispatched and processed,
+// returning a summary of how many succeeded and failed. If Reader is set and
+// a record fails to decode partway through, the error is returned and no key
+// after the bad record is attempted.
+func (c *Client) BulkInsertKVStoreKeys(i *BulkInsertKVStoreKeysInput) (*BulkStats, error) {
+	if i.ID == "" {
+		return nil, ErrMissingID
+	}
+
+	work := i.Keys
+	var decodeErr error
+	if work == nil {
+		work = decodeKVStoreKeyValues(i.Reader, &decodeErr)
+	}
+
+	stats, _ := c.runKVStoreBulk(i.Parallelism, i.StopOnError, i.Results, work, func(kv KVStoreKeyValue) (*http.Response, error) {
+		var resp *http.Response
+		err := c.observeKVStoreOp(context.Background(), "bulk_insert", i.ID, kv.Key, func() error {
+			var err error
+			path := "/resources/stores/kv/" + i.ID + "/keys/" + kv.Key
+			resp, err = c.Put(path, &RequestOptions{Body: io.NopCloser(strings.NewReader(kv.Value))})
+			return err
+		})
+		return resp, err
+	})
+	return stats, decodeErr
+}
+
+// BulkDeleteKVStoreKeysInput is the input to the BulkDeleteKVStoreKeys function.
+type BulkDeleteKVStoreKeysInput struct {
+	// ID is the ID of the kv store (required).
+	ID string
+	// Keys streams the keys to delete. Mutually exclusive with Reader; if
+	// both are nil, nothing is deleted.
+	Keys <-chan string
+	// Parallelism is the number of worker goroutines to delete with. Defaults to 1.
+	Parallelism int
+	// Reader, if set, is read as newline-delimited JSON {"key":"..."}
+	// records. Mutually exclusive with Keys.
+	Reader io.Reader
+	// Results, if set, receives a BulkResult for every key processed and is
+	// closed when the operation completes.
+	Results chan<- BulkResult
+	// StopOnError stops dispatching further keys as soon as one fails.
+	StopOnError bool
+}
+
+// BulkDeleteKVStoreKeys deletes many keys concurrently using the same
+// bounded worker pool and retry behavior as BulkInsertKVStoreKeys. If Reader
+// is set and a record fails to decode partway through, the error is returned
+// and no key after the bad record is attempted.
+func (c *Client) BulkDeleteKVStoreKeys(i *BulkDeleteKVStoreKeysInput) (*BulkStats, error) {
+	if i.ID == "" {
+		return nil, ErrMissingID
+	}
+
+	keys := i.Keys
+	var decodeErr error
+	if keys == nil {
+		keys = decodeKVStoreKeys(i.Reader, &decodeErr)
+	}
+
+	work := make(chan KVStoreKeyValue)
+	go func() {
+		defer close(work)
+		for key := range keys {
+			work <- KVStoreKeyValue{Key: key}
+		}
+	}()
+
+	stats, _ := c.runKVStoreBulk(i.Parallelism, i.StopOnError, i.Results, work, func(kv KVStoreKeyValue) (*http.Response, error) {
+		var resp *http.Response
+		err := c.observeKVStoreOp(context.Background(), "bulk_delete", i.ID, kv.Key, func() error {
+			var err error
+			path := "/resources/stores/kv/" + i.ID + "/keys/" + kv.Key
+			resp, err = c.Delete(path, nil)
+			return err
+		})
+		return resp, err
+	})
+	return stats, decodeErr
+}
+
+// decodeKVStoreKeyValues streams KVStoreKeyValue records decoded from r,
+// which is expected to hold newline-delimited JSON. A nil r yields a closed,
+// empty channel. If decoding fails partway through, the first error is
+// recorded in *decodeErr (which the caller must only read after the channel
+// is drained and closed) and the channel is closed without further output.
+func decodeKVStoreKeyValues(r io.Reader, decodeErr *error) <-chan KVStoreKeyValue {
+	out := make(chan KVStoreKeyValue)
+	go func() {
+		defer close(out)
+		if r == nil {
+			return
+		}
+		dec := json.NewDecoder(r)
+		for dec.More() {
+			var kv KVStoreKeyValue
+			if err := dec.Decode(&kv); err != nil {
+				*decodeErr = err
+				return
+			}
+			out <- kv
+		}
+	}()
+	return out
+}
+
+// decodeKVStoreKeys streams just the Key field of KVStoreKeyValue records
+// decoded from r. A nil r yields a closed, empty channel. See
+// decodeKVStoreKeyValues for decodeErr's semantics.
+func decodeKVStoreKeys(r io.Reader, decodeErr *error) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for kv := range decodeKVStoreKeyValues(r, decodeErr) {
+			out <- kv.Key
+		}
+	}()
+	return out
+}
+
+// runKVStoreBulk drives work over a bounded pool of worker goroutines,
+// retrying each item with retryKVStoreOp, and reports a summary once every
+// item has been processed.
+func (c *Client) runKVStoreBulk(parallelism int, stopOnError bool, results chan<- BulkResult, work <-chan KVStoreKeyValue, do func(KVStoreKeyValue) (*http.Response, error)) (*BulkStats, error) {
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	start := time.Now()
+	var stats BulkStats
+	var mu sync.Mutex
+	var stopped int32
+
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for n := 0; n < parallelism; n++ {
+		go func() {
+			defer wg.Done()
+			for kv := range work {
+				if stopOnError && atomic.LoadInt32(&stopped) == 1 {
+					continue
+				}
+
+				attempts, err := retryKVStoreOp(kv, do)
+
+				mu.Lock()
+				if err != nil {
+					stats.Failed++
+				} else {
+					stats.Succeeded++
+				}
+				mu.Unlock()
+
+				if err != nil && stopOnError {
+					atomic.StoreInt32(&stopped, 1)
+				}
+
+				if results != nil {
+					results <- BulkResult{Key: kv.Key, Err: err, Attempts: attempts}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if results != nil {
+		close(results)
+	}
+
+	stats.Duration = time.Since(start)
+	return &stats, nil
+}
+
+// retryKVStoreOp retries a single bulk operation on 429/5xx responses with
+// jittered exponential backoff, honoring the response's Retry-After header
+// when the server sends one. It drains and closes the response body on
+// every attempt so the underlying connection can be reused, which matters
+// most on the retry path where the server is already under load.
+func retryKVStoreOp(kv KVStoreKeyValue, do func(KVStoreKeyValue) (*http.Response, error)) (int, error) {
+	const maxAttempts = 5
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		resp, err := do(kv)
+		if resp == nil {
+			lastErr = err
+			if attempt == maxAttempts {
+				return attempt, lastErr
+			}
+			time.Sleep(kvStoreBulkBackoff(attempt, ""))
+			continue
+		}
+
+		status := resp.StatusCode
+		retryAfter := resp.Header.Get("Retry-After")
+
+		httpErr := err
+		if httpErr == nil && status >= http.StatusBadRequest {
+			httpErr = NewHTTPError(resp)
+		}
+		drainAndCloseKVStoreBody(resp.Body)
+
+		if httpErr == nil {
+			return attempt, nil
+		}
+
+		lastErr = httpErr
+		retryable := status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+		if !retryable || attempt == maxAttempts {
+			return attempt, lastErr
+		}
+		time.Sleep(kvStoreBulkBackoff(attempt, retryAfter))
+	}
+
+	return maxAttempts, lastErr
+}
+
+// drainAndCloseKVStoreBody reads resp.Body to completion and closes it so
+// the underlying connection can be reused by the transport's pool.
+func drainAndCloseKVStoreBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, body)
+	_ = body.Close()
+}
+
+// kvStoreBulkBackoff computes a jittered exponential backoff for bulk
+// operation retries, preferring the server's Retry-After header when present.
+func kvStoreBulkBackoff(attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+	}
+
+	base := time.Duration(1<<uint(attempt)) * 100 * time.Millisecond
+	jitter := time.Duration(mrand.Int63n(int64(base)))
+	return base + jitter
+}
+
+// BulkExportKVStoreInput is the input to the BulkExportKVStore function.
+type BulkExportKVStoreInput struct {
+	// ID is the ID of the kv store to export (required).
+	ID string
+	// Parallelism is the number of worker goroutines used to fetch values.
+	// Defaults to 1.
+	Parallelism int
+	// Writer receives one NDJSON KVStoreKeyValue record per exported key
+	// (required).
+	Writer io.Writer
+}
+
+// BulkExportKVStore walks every key in the store with the key paginator and
+// fetches each value using a bounded worker pool, writing one
+// newline-delimited JSON KVStoreKeyValue record per key to Writer. It is the
+// read-side counterpart to BulkInsertKVStoreKeys, making backup/restore a
+// first-class operation. If a write to Writer fails partway through (e.g.
+// disk full), the error is returned and the resulting backup must be
+// considered incomplete.
+func (c *Client) BulkExportKVStore(i *BulkExportKVStoreInput) (*BulkStats, error) {
+	if i.ID == "" {
+		return nil, ErrMissingID
+	}
+	if i.Writer == nil {
+		return nil, errors.New("fastly: Writer is required")
+	}
+
+	parallelism := i.Parallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	start := time.Now()
+	paginator := c.NewListKVStoreKeysPaginator(&ListKVStoreKeysInput{ID: i.ID})
+
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		for paginator.Next() {
+			for _, key := range paginator.Keys() {
+				keys <- key
+			}
+		}
+	}()
+
+	var stats BulkStats
+	var mu sync.Mutex
+	records := make(chan KVStoreKeyValue)
+
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for n := 0; n < parallelism; n++ {
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				value, err := c.GetKVStoreKey(&GetKVStoreKeyInput{ID: i.ID, Key: key})
+
+				mu.Lock()
+				if err != nil {
+					stats.Failed++
+				} else {
+					stats.Succeeded++
+				}
+				mu.Unlock()
+
+				if err == nil {
+					records <- KVStoreKeyValue{Key: key, Value: value}
+				}
+			}
+		}()
+	}
+
+	var encodeErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		enc := json.NewEncoder(i.Writer)
+		for rec := range records {
+			if encodeErr != nil {
+				continue // drain the rest so producers don't block, but stop writing
+			}
+			if err := enc.Encode(rec); err != nil {
+				encodeErr = err
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(records)
+	<-done
+
+	if err := paginator.Err(); err != nil {
+		return &stats, err
+	}
+	if encodeErr != nil {
+		return &stats, encodeErr
+	}
+
+	stats.Duration = time.Since(start)
+	return &stats, nil
+}
+
+// KVStoreScope is a namespaced view over a kv store that transparently
+// prepends a prefix to every key passed to Get, Insert, Delete, List, and
+// Watch, and strips it from every key returned, so independent subsystems
+// can share one kv store without threading the prefix through every call
+// site. Prefix bytes are treated opaquely; callers that want delimiter-style
+// grouping still set Delimiter on the ListKVStoreKeysInput passed to List.
+type KVStoreScope struct {
+	client  *Client
+	prefix  string
+	storeID string
+}
+
+// KVStoreScope returns a KVStoreScope over storeID namespaced under prefix.
+func (c *Client) KVStoreScope(storeID, prefix string) *KVStoreScope {
+	return &KVStoreScope{client: c, storeID: storeID, prefix: prefix}
+}
+
+// Sub returns a nested KVStoreScope further namespaced under prefix,
+// appended opaquely to the parent scope's prefix.
+func (s *KVStoreScope) Sub(prefix string) *KVStoreScope {
+	return &KVStoreScope{client: s.client, storeID: s.storeID, prefix: s.prefix + prefix}
+}
+
+// Get retrieves the value for key within the scope's namespace.
+func (s *KVStoreScope) Get(key string) (string, error) {
+	return s.client.GetKVStoreKey(&GetKVStoreKeyInput{ID: s.storeID, Key: s.prefix + key})
+}
+
+// Insert writes value for key within the scope's namespace.
+func (s *KVStoreScope) Insert(key, value string) error {
+	return s.client.InsertKVStoreKey(&InsertKVStoreKeyInput{ID: s.storeID, Key: s.prefix + key, Value: value})
+}
+
+// Delete removes key within the scope's namespace.
+func (s *KVStoreScope) Delete(key string) error {
+	return s.client.DeleteKVStoreKey(&DeleteKVStoreKeyInput{ID: s.storeID, Key: s.prefix + key})
+}
+
+// List returns the keys within the scope's namespace, with the scope's
+// prefix stripped from every returned key.
+func (s *KVStoreScope) List(i *ListKVStoreKeysInput) (*ListKVStoreKeysResponse, error) {
+	if i == nil {
+		i = new(ListKVStoreKeysInput)
+	}
+
+	scoped := *i
+	scoped.ID = s.storeID
+	scoped.Prefix = s.prefix + i.Prefix
+
+	output, err := s.client.ListKVStoreKeys(&scoped)
+	if err != nil {
+		return nil, err
+	}
+
+	stripKVStoreScopePrefix(output, s.prefix)
+	return output, nil
+}
+
+// Watch starts a KVStoreWatcher scoped to the namespace; events it delivers
+// have the scope's prefix stripped from their Key.
+func (s *KVStoreScope) Watch(ctx context.Context, i *WatchKVStoreInput) (*KVStoreWatcher, error) {
+	if i == nil {
+		i = new(WatchKVStoreInput)
+	}
+
+	scoped := *i
+	scoped.ID = s.storeID
+	scoped.Prefix = s.prefix + i.Prefix
+
+	w, err := s.client.WatchKVStore(ctx, &scoped)
+	if err != nil {
+		return nil, err
+	}
+
+	// relayCtx is derived from ctx and is also cancelled by Stop(), so the
+	// forwarding goroutine below exits on either one even when the consumer
+	// has stopped draining Events(). Without either, it waits on its send.
+	relayCtx, relayCancel := context.WithCancel(ctx)
+	scopedWatcher := &KVStoreWatcher{done: w.done, events: make(chan KVStoreEvent)}
+	scopedWatcher.cancel = func() {
+		w.cancel()
+		relayCancel()
+	}
+
+	go func() {
+		defer close(scopedWatcher.events)
+		for {
+			select {
+			case ev, ok := <-w.events:
+				if !ok {
+					scopedWatcher.err = w.err
+					return
+				}
+				ev.Key = strings.TrimPrefix(ev.Key, s.prefix)
+				select {
+				case scopedWatcher.events <- ev:
+				case <-relayCtx.Done():
+					return
+				}
+			case <-relayCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return scopedWatcher, nil
+}
+
+// stripKVStoreScopePrefix strips prefix from every key in a ListKVStoreKeys
+// response, in place.
+func stripKVStoreScopePrefix(output *ListKVStoreKeysResponse, prefix string) {
+	for idx, key := range output.Data {
+		output.Data[idx] = strings.TrimPrefix(key, prefix)
+	}
+	for idx, key := range output.PrefixMatchedKeys {
+		output.PrefixMatchedKeys[idx] = strings.TrimPrefix(key, prefix)
+	}
+	for idx, key := range output.CommonPrefixes {
+		output.CommonPrefixes[idx] = strings.TrimPrefix(key, prefix)
+	}
+}
+
+// KVStoreObserver receives a notification for every KV store operation a
+// Client performs, making it possible to add metrics or tracing without
+// wrapping each call site by hand.
+type KVStoreObserver interface {
+	// ObserveOp is called once per operation with the context the call was
+	// made under (so e.g. a tracing observer can nest its span under the
+	// request that triggered the call, rather than a single context
+	// captured once at construction), the operation name ("get", "insert",
+	// "delete", "list", "get_metadata", "insert_atomic", "delete_atomic",
+	// "bulk_insert", or "bulk_delete"; BulkExportKVStore reports its calls as
+	// "list" and "get"), the store ID, the key (empty for "list"; the
+	// individual record's key for bulk operations), how long the call took,
+	// and the error it returned, if any.
+	ObserveOp(ctx context.Context, op string, storeID string, key string, dur time.Duration, err error)
+}
+
+// SetKVStoreObserver installs obs to receive a notification for every KV
+// store operation performed through c. Pass nil to stop observing.
+//
+// The observer is held in c.kvStoreObserver (guarded by
+// c.kvStoreObserverMu), not a package-level registry keyed by client
+// pointer, so it's released along with c rather than outliving it.
+func (c *Client) SetKVStoreObserver(obs KVStoreObserver) {
+	c.kvStoreObserverMu.Lock()
+	defer c.kvStoreObserverMu.Unlock()
+	c.kvStoreObserver = obs
+}
+
+// loadKVStoreObserver returns the KVStoreObserver installed on c, if any.
+func (c *Client) loadKVStoreObserver() KVStoreObserver {
+	c.kvStoreObserverMu.RLock()
+	defer c.kvStoreObserverMu.RUnlock()
+	return c.kvStoreObserver
+}
+
+// observeKVStoreOp times fn and reports it to c's configured
+// KVStoreObserver, if any, before returning fn's error. ctx is passed through
+// to the observer unchanged; callers with no real request context should
+// pass context.Background().
+func (c *Client) observeKVStoreOp(ctx context.Context, op, storeID, key string, fn func() error) error {
+	obs := c.loadKVStoreObserver()
+	if obs == nil {
+		return fn()
+	}
+
+	start := time.Now()
+	err := fn()
+	obs.ObserveOp(ctx, op, storeID, key, time.Since(start), err)
+	return err
 }
\ No newline at end of file
@@ -0,0 +1,39 @@
+// Package kvobserver provides ready-made fastly.KVStoreObserver
+// implementations for Prometheus metrics and OpenTelemetry tracing.
+package kvobserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusKVStoreObserver is a fastly.KVStoreObserver that records KV store
+// operation latency as a Prometheus histogram, labeled by operation, store,
+// and outcome.
+type PrometheusKVStoreObserver struct {
+	histogram *prometheus.HistogramVec
+}
+
+// NewPrometheusKVStoreObserver creates a PrometheusKVStoreObserver and
+// registers its histogram (fastly_kv_op_seconds{op,store,outcome}) with reg.
+func NewPrometheusKVStoreObserver(reg prometheus.Registerer) *PrometheusKVStoreObserver {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "fastly_kv_op_seconds",
+		Help: "Duration of Fastly KV store operations in seconds.",
+	}, []string{"op", "store", "outcome"})
+
+	reg.MustRegister(histogram)
+
+	return &PrometheusKVStoreObserver{histogram: histogram}
+}
+
+// ObserveOp implements fastly.KVStoreObserver.
+func (o *PrometheusKVStoreObserver) ObserveOp(_ context.Context, op, storeID, _ string, dur time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	o.histogram.WithLabelValues(op, storeID, outcome).Observe(dur.Seconds())
+}
@@ -0,0 +1,42 @@
+package kvobserver
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingKVStoreObserver is a fastly.KVStoreObserver that starts an
+// OpenTelemetry span for every KV store operation, annotated with the
+// operation, store, key length, and outcome. Each span is started against
+// the context ObserveOp is called with, so it nests under whatever request
+// actually triggered the call rather than a single context captured once at
+// construction.
+type TracingKVStoreObserver struct {
+	tracer trace.Tracer
+}
+
+// NewTracingKVStoreObserver creates a TracingKVStoreObserver that starts
+// spans using the named tracer from the global OpenTelemetry provider.
+func NewTracingKVStoreObserver(tracerName string) *TracingKVStoreObserver {
+	return &TracingKVStoreObserver{tracer: otel.Tracer(tracerName)}
+}
+
+// ObserveOp implements fastly.KVStoreObserver.
+func (o *TracingKVStoreObserver) ObserveOp(ctx context.Context, op, storeID, key string, dur time.Duration, err error) {
+	_, span := o.tracer.Start(ctx, "fastly.kv."+op, trace.WithAttributes(
+		attribute.String("fastly.kv.store", storeID),
+		attribute.Int("fastly.kv.key_length", len(key)),
+		attribute.Int64("fastly.kv.duration_us", dur.Microseconds()),
+	))
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}